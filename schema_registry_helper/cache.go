@@ -0,0 +1,145 @@
+package schema_registry_helper
+
+import (
+	"sync"
+	"time"
+)
+
+// Cache is the pluggable storage backing SchemaRegistryClient's
+// lookups by id and by subject/version. Implementations must be safe
+// for concurrent use.
+type Cache interface {
+	// Get returns the value stored under key, if any.
+	Get(key interface{}) (interface{}, bool)
+	// Put stores value under key.
+	Put(key interface{}, value interface{})
+	// Delete removes key, if present.
+	Delete(key interface{})
+	// Clear removes everything from the cache.
+	Clear()
+}
+
+// mapCache is an unbounded, never-expiring Cache. This is the
+// original caching behavior of SchemaRegistryClient and remains the
+// default for the id and subject/version caches, since schemas are
+// immutable once registered.
+type mapCache struct {
+	mu     sync.RWMutex
+	values map[interface{}]interface{}
+}
+
+func newMapCache() *mapCache {
+	return &mapCache{values: make(map[interface{}]interface{})}
+}
+
+func (c *mapCache) Get(key interface{}) (interface{}, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	value, ok := c.values[key]
+	return value, ok
+}
+
+func (c *mapCache) Put(key interface{}, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = value
+}
+
+func (c *mapCache) Delete(key interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.values, key)
+}
+
+func (c *mapCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values = make(map[interface{}]interface{})
+}
+
+// ttlCache is a Cache whose entries expire ttl after being written. It
+// is used for "latest" subject lookups, which must be periodically
+// refetched since the underlying schema can gain new versions. A
+// background ticker sweeps expired entries so that long-idle caches
+// do not retain stale data indefinitely; stop it with Close.
+type ttlCache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[interface{}]ttlEntry
+
+	stop chan struct{}
+	once sync.Once
+}
+
+type ttlEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+func newTTLCache(ttl time.Duration) *ttlCache {
+	c := &ttlCache{
+		ttl:     ttl,
+		entries: make(map[interface{}]ttlEntry),
+		stop:    make(chan struct{}),
+	}
+	go c.sweep()
+	return c
+}
+
+func (c *ttlCache) sweep() {
+	interval := c.ttl
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			c.mu.Lock()
+			for key, entry := range c.entries {
+				if now.After(entry.expires) {
+					delete(c.entries, key)
+				}
+			}
+			c.mu.Unlock()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+func (c *ttlCache) Get(key interface{}) (interface{}, bool) {
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (c *ttlCache) Put(key interface{}, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = ttlEntry{value: value, expires: time.Now().Add(c.ttl)}
+}
+
+func (c *ttlCache) Delete(key interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+func (c *ttlCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[interface{}]ttlEntry)
+}
+
+// Close stops the background sweep goroutine. It is safe to call
+// more than once.
+func (c *ttlCache) Close() {
+	c.once.Do(func() { close(c.stop) })
+}
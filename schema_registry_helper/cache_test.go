@@ -0,0 +1,61 @@
+package schema_registry_helper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLCacheExpiresEntriesAfterTTL(t *testing.T) {
+	c := newTTLCache(20 * time.Millisecond)
+	defer c.Close()
+
+	c.Put("key", "value")
+	if _, ok := c.Get("key"); !ok {
+		t.Fatal("expected entry to be present immediately after Put")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := c.Get("key"); ok {
+		t.Error("expected entry to have expired, but it was still present")
+	}
+}
+
+func TestTTLCacheSweepRemovesExpiredEntries(t *testing.T) {
+	c := newTTLCache(10 * time.Millisecond)
+	defer c.Close()
+
+	c.Put("key", "value")
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.RLock()
+		_, stillStored := c.entries["key"]
+		c.mu.RUnlock()
+		if !stillStored {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("background sweep never removed the expired entry from the underlying map")
+}
+
+func TestTTLCacheCloseIsIdempotent(t *testing.T) {
+	c := newTTLCache(time.Minute)
+	c.Close()
+	c.Close() // must not panic
+}
+
+func TestMapCacheNeverExpires(t *testing.T) {
+	c := newMapCache()
+	c.Put("key", "value")
+
+	value, ok := c.Get("key")
+	if !ok || value != "value" {
+		t.Fatalf("Get() = (%v, %v), want (%q, true)", value, ok, "value")
+	}
+
+	c.Delete("key")
+	if _, ok := c.Get("key"); ok {
+		t.Error("expected entry to be gone after Delete")
+	}
+}
@@ -0,0 +1,161 @@
+package schema_registry_helper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Compatibility levels accepted by Schema Registry's /config endpoints.
+const (
+	BackwardCompatibility           = "BACKWARD"
+	BackwardTransitiveCompatibility = "BACKWARD_TRANSITIVE"
+	ForwardCompatibility            = "FORWARD"
+	ForwardTransitiveCompatibility  = "FORWARD_TRANSITIVE"
+	FullCompatibility               = "FULL"
+	FullTransitiveCompatibility     = "FULL_TRANSITIVE"
+	NoneCompatibility               = "NONE"
+
+	compatibilitySubjectVersion = "/compatibility/subjects/%s/versions/%s"
+	configSubject               = "/config/%s"
+	configGlobal                = "/config"
+)
+
+type compatibilityResponse struct {
+	IsCompatible bool `json:"is_compatible"`
+}
+
+type compatibilityLevelResponse struct {
+	CompatibilityLevel string `json:"compatibilityLevel"`
+}
+
+type compatibilityLevelRequest struct {
+	Compatibility string `json:"compatibility"`
+}
+
+// TestCompatibility checks whether schema would be compatible with
+// the given version of subject, according to Schema Registry's
+// configured compatibility level. It calls
+// POST /compatibility/subjects/{subject}/versions/{version}.
+func (client *SchemaRegistryClient) TestCompatibility(subject, schema string,
+	schemaType SchemaType, version string, references ...Reference) (bool, error) {
+	return client.TestCompatibilityContext(context.Background(), subject, schema, schemaType, version, references...)
+}
+
+// TestCompatibilityContext is TestCompatibility with a caller-supplied context.
+func (client *SchemaRegistryClient) TestCompatibilityContext(ctx context.Context, subject, schema string,
+	schemaType SchemaType, version string, references ...Reference) (bool, error) {
+
+	payload, err := createPayload(schema, schemaType, references)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := client.httpRequestContext(ctx, "POST", fmt.Sprintf(compatibilitySubjectVersion, subject, version), payload)
+	if err != nil {
+		return false, err
+	}
+
+	var compatResp compatibilityResponse
+	if err := json.Unmarshal(resp, &compatResp); err != nil {
+		return false, err
+	}
+	return compatResp.IsCompatible, nil
+}
+
+// GetCompatibilityLevel returns the compatibility level configured
+// for subject, or Schema Registry's global default if subject has no
+// subject-level override.
+func (client *SchemaRegistryClient) GetCompatibilityLevel(subject string) (string, error) {
+	return client.GetCompatibilityLevelContext(context.Background(), subject)
+}
+
+// GetCompatibilityLevelContext is GetCompatibilityLevel with a caller-supplied context.
+func (client *SchemaRegistryClient) GetCompatibilityLevelContext(ctx context.Context, subject string) (string, error) {
+
+	resp, err := client.httpRequestContext(ctx, "GET", fmt.Sprintf(configSubject, subject), nil)
+	if err != nil {
+		return "", err
+	}
+
+	var levelResp compatibilityLevelResponse
+	if err := json.Unmarshal(resp, &levelResp); err != nil {
+		return "", err
+	}
+	return levelResp.CompatibilityLevel, nil
+}
+
+// ChangeCompatibilityLevel sets the compatibility level for subject.
+// Valid levels are BACKWARD, BACKWARD_TRANSITIVE, FORWARD,
+// FORWARD_TRANSITIVE, FULL, FULL_TRANSITIVE, and NONE.
+func (client *SchemaRegistryClient) ChangeCompatibilityLevel(subject, level string) error {
+	return client.ChangeCompatibilityLevelContext(context.Background(), subject, level)
+}
+
+// ChangeCompatibilityLevelContext is ChangeCompatibilityLevel with a caller-supplied context.
+func (client *SchemaRegistryClient) ChangeCompatibilityLevelContext(ctx context.Context, subject, level string) error {
+
+	body, err := json.Marshal(compatibilityLevelRequest{Compatibility: level})
+	if err != nil {
+		return err
+	}
+
+	_, err = client.httpRequestContext(ctx, "PUT", fmt.Sprintf(configSubject, subject), bytes.NewBuffer(body))
+	return err
+}
+
+// CreateSchemaWithCompatibility creates a schema exactly like
+// CreateSchema, then sets the compatibility level on the resulting
+// subject in the same call.
+func (client *SchemaRegistryClient) CreateSchemaWithCompatibility(subject, schema string,
+	schemaType SchemaType, isKey bool, level string, references ...Reference) (*Schema, error) {
+	return client.CreateSchemaWithCompatibilityContext(context.Background(), subject, schema, schemaType, isKey, level, references...)
+}
+
+// CreateSchemaWithCompatibilityContext is CreateSchemaWithCompatibility with a caller-supplied context.
+func (client *SchemaRegistryClient) CreateSchemaWithCompatibilityContext(ctx context.Context, subject, schema string,
+	schemaType SchemaType, isKey bool, level string, references ...Reference) (*Schema, error) {
+
+	newSchema, err := client.CreateSchemaContext(ctx, subject, schema, schemaType, isKey, references...)
+	if err != nil {
+		return nil, err
+	}
+
+	concreteSubject := getConcreteSubject(subject, isKey)
+	if err := client.ChangeCompatibilityLevelContext(ctx, concreteSubject, level); err != nil {
+		return nil, err
+	}
+
+	return newSchema, nil
+}
+
+// ExportSchemaWithCompatibility behaves like ExportSchema, but also
+// sets the compatibility level on the subject when a new schema is
+// created. The compatibility level of an already-existing subject is
+// left untouched.
+func ExportSchemaWithCompatibility(schemaBytes []byte, topic string, schemaType SchemaType, level string, src SchemaRegistryClient) (int, error) {
+	schema, err := src.CheckSchema(topic, string(schemaBytes), schemaType, false)
+	if err != nil && !strings.Contains(err.Error(), ErrNotFound) {
+		return -1, err
+	} else if err != nil {
+		// A new version of a subject that already has versions keeps
+		// the subject's current level.
+		if _, verr := src.GetSchemaVersions(topic, false, false); verr == nil {
+			schema, err := src.CreateSchema(topic, string(schemaBytes), schemaType, false)
+			if err != nil {
+				return -1, err
+			}
+			return schema.Version(), nil
+		} else if !strings.Contains(verr.Error(), ErrNotFound) {
+			return -1, verr
+		}
+		schema, err := src.CreateSchemaWithCompatibility(topic, string(schemaBytes), schemaType, false, level)
+		if err != nil {
+			return -1, err
+		}
+		return schema.Version(), nil
+	}
+	return schema.Version, nil
+}
@@ -0,0 +1,78 @@
+package schema_registry_helper
+
+import (
+	"testing"
+
+	"github.com/ssh2go/schema-registry-helper/schema_registry_helper/srtest"
+)
+
+func TestGetCompatibilityLevelDefaultsToBackward(t *testing.T) {
+	fake := srtest.NewFakeRegistryServer()
+	defer fake.Close()
+	client := CreateSchemaRegistryClient(fake.URL)
+	defer client.Close()
+
+	level, err := client.GetCompatibilityLevel("widget-value")
+	if err != nil {
+		t.Fatalf("GetCompatibilityLevel: %v", err)
+	}
+	if level != BackwardCompatibility {
+		t.Errorf("level = %q, want %q", level, BackwardCompatibility)
+	}
+}
+
+func TestChangeCompatibilityLevelThenGet(t *testing.T) {
+	fake := srtest.NewFakeRegistryServer()
+	defer fake.Close()
+	client := CreateSchemaRegistryClient(fake.URL)
+	defer client.Close()
+
+	if err := client.ChangeCompatibilityLevel("widget-value", FullCompatibility); err != nil {
+		t.Fatalf("ChangeCompatibilityLevel: %v", err)
+	}
+
+	level, err := client.GetCompatibilityLevel("widget-value")
+	if err != nil {
+		t.Fatalf("GetCompatibilityLevel: %v", err)
+	}
+	if level != FullCompatibility {
+		t.Errorf("level = %q, want %q", level, FullCompatibility)
+	}
+}
+
+func TestTestCompatibility(t *testing.T) {
+	fake := srtest.NewFakeRegistryServer()
+	defer fake.Close()
+	client := CreateSchemaRegistryClient(fake.URL)
+	defer client.Close()
+
+	compatible, err := client.TestCompatibility("widget-value",
+		`{"type":"record","name":"Widget","fields":[]}`, Avro, "latest")
+	if err != nil {
+		t.Fatalf("TestCompatibility: %v", err)
+	}
+	if !compatible {
+		t.Error("expected the fake registry's compatibility check to always report compatible")
+	}
+}
+
+func TestCreateSchemaWithCompatibilitySetsLevel(t *testing.T) {
+	fake := srtest.NewFakeRegistryServer()
+	defer fake.Close()
+	client := CreateSchemaRegistryClient(fake.URL)
+	defer client.Close()
+
+	_, err := client.CreateSchemaWithCompatibility("widget",
+		`{"type":"record","name":"Widget","fields":[]}`, Avro, false, NoneCompatibility)
+	if err != nil {
+		t.Fatalf("CreateSchemaWithCompatibility: %v", err)
+	}
+
+	level, err := client.GetCompatibilityLevel("widget-value")
+	if err != nil {
+		t.Fatalf("GetCompatibilityLevel: %v", err)
+	}
+	if level != NoneCompatibility {
+		t.Errorf("level = %q, want %q", level, NoneCompatibility)
+	}
+}
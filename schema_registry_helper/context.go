@@ -0,0 +1,16 @@
+package schema_registry_helper
+
+import "context"
+
+// cacheGetContext reads key from cache, failing fast if ctx is already
+// done rather than returning a stale cache hit to a cancelled caller.
+// Cache implementations only ever hold their lock for a map read/write,
+// so unlike an HTTP round trip there is nothing here worth cancelling
+// mid-flight.
+func cacheGetContext(ctx context.Context, cache Cache, key interface{}) (interface{}, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	value, ok := cache.Get(key)
+	return value, ok, nil
+}
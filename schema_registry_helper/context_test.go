@@ -0,0 +1,43 @@
+package schema_registry_helper
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCacheGetContextReturnsValue(t *testing.T) {
+	c := newMapCache()
+	c.Put("key", "value")
+
+	value, ok, err := cacheGetContext(context.Background(), c, "key")
+	if err != nil {
+		t.Fatalf("cacheGetContext: %v", err)
+	}
+	if !ok || value != "value" {
+		t.Errorf("cacheGetContext = (%v, %v), want (%q, true)", value, ok, "value")
+	}
+}
+
+func TestCacheGetContextReturnsMissForAbsentKey(t *testing.T) {
+	c := newMapCache()
+	_, ok, err := cacheGetContext(context.Background(), c, "missing")
+	if err != nil {
+		t.Fatalf("cacheGetContext: %v", err)
+	}
+	if ok {
+		t.Error("expected ok=false for an absent key")
+	}
+}
+
+func TestCacheGetContextHonorsCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := newMapCache()
+	c.Put("key", "value")
+
+	_, _, err := cacheGetContext(ctx, c, "key")
+	if err != ctx.Err() {
+		t.Errorf("err = %v, want %v", err, ctx.Err())
+	}
+}
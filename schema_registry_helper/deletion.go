@@ -0,0 +1,105 @@
+package schema_registry_helper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	allSubjects          = "/subjects"
+	subjectDelete        = "/subjects/%s?permanent=%t"
+	subjectVersionDelete = "/subjects/%s/versions/%s?permanent=%t"
+)
+
+// GetAllSubjects returns every subject registered in Schema Registry.
+// When includeDeleted is true, subjects that have been soft-deleted
+// (but not permanently removed) are included too.
+func (client *SchemaRegistryClient) GetAllSubjects(includeDeleted bool) ([]string, error) {
+	return client.GetAllSubjectsContext(context.Background(), includeDeleted)
+}
+
+// GetAllSubjectsContext is GetAllSubjects with a caller-supplied context.
+func (client *SchemaRegistryClient) GetAllSubjectsContext(ctx context.Context, includeDeleted bool) ([]string, error) {
+
+	uri := allSubjects
+	if includeDeleted {
+		uri = allSubjects + "?deleted=true"
+	}
+
+	resp, err := client.httpRequestContext(ctx, "GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var subjects []string
+	if err := json.Unmarshal(resp, &subjects); err != nil {
+		return nil, err
+	}
+	return subjects, nil
+}
+
+// DeleteSubject deletes a subject, returning the versions that were
+// deleted. By default this is a soft delete: the subject can still be
+// looked up with deleted=true and the version numbers cannot be
+// reused. Pass permanent=true to also remove the soft-deleted
+// subject, which requires it to have already been soft-deleted.
+func (client *SchemaRegistryClient) DeleteSubject(subject string, isKey, permanent bool) ([]int, error) {
+	return client.DeleteSubjectContext(context.Background(), subject, isKey, permanent)
+}
+
+// DeleteSubjectContext is DeleteSubject with a caller-supplied context.
+func (client *SchemaRegistryClient) DeleteSubjectContext(ctx context.Context, subject string, isKey, permanent bool) ([]int, error) {
+
+	concreteSubject := getConcreteSubject(subject, isKey)
+	resp, err := client.httpRequestContext(ctx, "DELETE", fmt.Sprintf(subjectDelete, concreteSubject, permanent), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []int
+	if err := json.Unmarshal(resp, &versions); err != nil {
+		return nil, err
+	}
+
+	client.invalidateSubject(concreteSubject, versions)
+	return versions, nil
+}
+
+// DeleteSubjectVersion deletes a single version of a subject, the
+// same way DeleteSubject does but scoped to one version.
+func (client *SchemaRegistryClient) DeleteSubjectVersion(subject string, version int, isKey, permanent bool) (int, error) {
+	return client.DeleteSubjectVersionContext(context.Background(), subject, version, isKey, permanent)
+}
+
+// DeleteSubjectVersionContext is DeleteSubjectVersion with a caller-supplied context.
+func (client *SchemaRegistryClient) DeleteSubjectVersionContext(ctx context.Context, subject string, version int, isKey, permanent bool) (int, error) {
+
+	concreteSubject := getConcreteSubject(subject, isKey)
+	resp, err := client.httpRequestContext(ctx, "DELETE", fmt.Sprintf(subjectVersionDelete, concreteSubject, fmt.Sprint(version), permanent), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var deletedVersion int
+	if err := json.Unmarshal(resp, &deletedVersion); err != nil {
+		return 0, err
+	}
+
+	client.invalidateSubject(concreteSubject, []int{deletedVersion})
+	return deletedVersion, nil
+}
+
+// invalidateSubject drops any cached entries for concreteSubject so
+// that a subsequent lookup reflects the deletion instead of serving
+// now-stale data from the id, subject/version, or latest caches.
+func (client *SchemaRegistryClient) invalidateSubject(concreteSubject string, versions []int) {
+	for _, version := range versions {
+		cacheKey := cacheKey(concreteSubject, fmt.Sprint(version))
+		if cached, ok := client.subjectSchemaCache.Get(cacheKey); ok {
+			client.idSchemaCache.Delete(cached.(*Schema).id)
+		}
+		client.subjectSchemaCache.Delete(cacheKey)
+	}
+	client.latestSchemaCache.Delete(concreteSubject)
+}
@@ -0,0 +1,88 @@
+package schema_registry_helper
+
+import (
+	"testing"
+
+	"github.com/ssh2go/schema-registry-helper/schema_registry_helper/srtest"
+)
+
+func TestDeleteSubjectInvalidatesLatestCache(t *testing.T) {
+	fake := srtest.NewFakeRegistryServer()
+	defer fake.Close()
+	client := CreateSchemaRegistryClient(fake.URL)
+	defer client.Close()
+
+	fake.Seed("widget-value", `{"type":"record","name":"Widget","fields":[]}`, "AVRO")
+	if _, err := client.GetLatestSchema("widget", false); err != nil {
+		t.Fatalf("GetLatestSchema: %v", err)
+	}
+
+	versions, err := client.DeleteSubject("widget", false, false)
+	if err != nil {
+		t.Fatalf("DeleteSubject: %v", err)
+	}
+	if len(versions) != 1 || versions[0] != 1 {
+		t.Errorf("versions = %v, want [1]", versions)
+	}
+
+	if _, ok := client.latestSchemaCache.Get("widget-value"); ok {
+		t.Error("expected DeleteSubject to invalidate the cached latest schema")
+	}
+}
+
+func TestDeleteSubjectVersionMakesItUnfetchable(t *testing.T) {
+	fake := srtest.NewFakeRegistryServer()
+	defer fake.Close()
+	client := CreateSchemaRegistryClient(fake.URL)
+	defer client.Close()
+
+	fake.Seed("widget-value", `{"type":"record","name":"Widget","fields":[]}`, "AVRO")
+
+	version, err := client.DeleteSubjectVersion("widget", 1, false, false)
+	if err != nil {
+		t.Fatalf("DeleteSubjectVersion: %v", err)
+	}
+	if version != 1 {
+		t.Errorf("version = %d, want 1", version)
+	}
+
+	if _, err := client.GetSchemaByVersion("widget", 1, false); err == nil {
+		t.Error("expected fetching a deleted version to fail")
+	}
+}
+
+func TestGetAllSubjectsExcludesSoftDeletedByDefault(t *testing.T) {
+	fake := srtest.NewFakeRegistryServer()
+	defer fake.Close()
+	client := CreateSchemaRegistryClient(fake.URL)
+	defer client.Close()
+
+	fake.Seed("widget-value", `{"type":"record","name":"Widget","fields":[]}`, "AVRO")
+	if _, err := client.DeleteSubject("widget", false, false); err != nil {
+		t.Fatalf("DeleteSubject: %v", err)
+	}
+
+	subjects, err := client.GetAllSubjects(false)
+	if err != nil {
+		t.Fatalf("GetAllSubjects: %v", err)
+	}
+	for _, s := range subjects {
+		if s == "widget-value" {
+			t.Errorf("expected soft-deleted subject %q to be excluded, got %v", "widget-value", subjects)
+		}
+	}
+
+	withDeleted, err := client.GetAllSubjects(true)
+	if err != nil {
+		t.Fatalf("GetAllSubjects(includeDeleted): %v", err)
+	}
+	found := false
+	for _, s := range withDeleted {
+		if s == "widget-value" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected soft-deleted subject %q to be included when includeDeleted=true, got %v", "widget-value", withDeleted)
+	}
+}
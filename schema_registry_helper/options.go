@@ -0,0 +1,77 @@
+package schema_registry_helper
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// TokenSource supplies a bearer token for OAuth/OIDC-authenticated
+// Schema Registry deployments. Token is called before each request;
+// implementations are expected to cache the token internally until it
+// expires.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// ClientOption configures a SchemaRegistryClient created via
+// CreateSchemaRegistryClientWithOptions.
+type ClientOption func(*SchemaRegistryClient)
+
+// WithBasicAuth configures HTTP basic auth, the same as calling
+// SetCredentials after construction.
+func WithBasicAuth(username, password string) ClientOption {
+	return func(client *SchemaRegistryClient) {
+		client.SetCredentials(username, password)
+	}
+}
+
+// WithBearerToken configures a static bearer token sent as the
+// Authorization header on every request.
+func WithBearerToken(token string) ClientOption {
+	return func(client *SchemaRegistryClient) {
+		client.bearerToken = token
+	}
+}
+
+// WithTokenSource configures a TokenSource consulted before every
+// request, for OAuth/OIDC deployments where the token must be
+// refreshed periodically. It takes precedence over WithBearerToken
+// and WithBasicAuth.
+func WithTokenSource(ts TokenSource) ClientOption {
+	return func(client *SchemaRegistryClient) {
+		client.tokenSource = ts
+	}
+}
+
+// WithHTTPClient overrides the *http.Client used for all requests,
+// e.g. to install a custom http.RoundTripper or set a different
+// timeout than SetTimeout allows.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(client *SchemaRegistryClient) {
+		client.httpClient = httpClient
+	}
+}
+
+// WithRetry enables automatic retry with exponential backoff for
+// requests that fail with 408, 429, or 5xx. The nth retry waits base *
+// 2^(n-1) (so the first retry waits base, the second 2*base, and so
+// on), or the duration given by a Retry-After response header when
+// present, up to max retries after the initial attempt (max+1 attempts
+// in all).
+func WithRetry(max int, base time.Duration) ClientOption {
+	return func(client *SchemaRegistryClient) {
+		client.maxRetries = max
+		client.retryBase = base
+	}
+}
+
+// CreateSchemaRegistryClientWithOptions creates a client the same way
+// CreateSchemaRegistryClient does, then applies opts in order.
+func CreateSchemaRegistryClientWithOptions(schemaRegistryURL string, opts ...ClientOption) *SchemaRegistryClient {
+	client := CreateSchemaRegistryClient(schemaRegistryURL)
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client
+}
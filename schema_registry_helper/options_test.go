@@ -0,0 +1,102 @@
+package schema_registry_helper
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIsRetryableStatus(t *testing.T) {
+	cases := map[int]bool{
+		http.StatusOK:                  false,
+		http.StatusNotFound:            false,
+		http.StatusRequestTimeout:      true,
+		http.StatusTooManyRequests:     true,
+		http.StatusInternalServerError: true,
+		http.StatusBadGateway:          true,
+	}
+	for status, want := range cases {
+		if got := isRetryableStatus(status); got != want {
+			t.Errorf("isRetryableStatus(%d) = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestRetryDelayExponentialBackoff(t *testing.T) {
+	base := 10 * time.Millisecond
+	if got := retryDelay(base, 1, ""); got != base {
+		t.Errorf("retryDelay(attempt=1) = %v, want %v", got, base)
+	}
+	if got := retryDelay(base, 2, ""); got != 2*base {
+		t.Errorf("retryDelay(attempt=2) = %v, want %v", got, 2*base)
+	}
+	if got := retryDelay(base, 3, ""); got != 4*base {
+		t.Errorf("retryDelay(attempt=3) = %v, want %v", got, 4*base)
+	}
+}
+
+func TestRetryDelayHonorsRetryAfterHeader(t *testing.T) {
+	got := retryDelay(10*time.Millisecond, 1, "2")
+	if want := 2 * time.Second; got != want {
+		t.Errorf("retryDelay with Retry-After=2 = %v, want %v", got, want)
+	}
+}
+
+func TestHTTPRequestContextRetriesOnRetryableStatus(t *testing.T) {
+	var attempts int
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		status := http.StatusServiceUnavailable
+		if attempts == 3 {
+			status = http.StatusOK
+		}
+		return newStatusResponse(status), nil
+	})
+
+	client := CreateSchemaRegistryClientWithOptions("http://registry.invalid",
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithRetry(5, time.Millisecond))
+	defer client.Close()
+
+	if _, err := client.httpRequest("GET", "/subjects", nil); err != nil {
+		t.Fatalf("httpRequest: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("made %d attempts, want 3", attempts)
+	}
+}
+
+func TestHTTPRequestContextGivesUpAfterMaxRetries(t *testing.T) {
+	var attempts int
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return newStatusResponse(http.StatusServiceUnavailable), nil
+	})
+
+	client := CreateSchemaRegistryClientWithOptions("http://registry.invalid",
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithRetry(2, time.Millisecond))
+	defer client.Close()
+
+	if _, err := client.httpRequest("GET", "/subjects", nil); err == nil {
+		t.Fatal("expected an error after exhausting retries, got nil")
+	}
+	if attempts != 3 {
+		t.Errorf("made %d attempts, want 3 (1 initial + 2 retries)", attempts)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func newStatusResponse(status int) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Body:       http.NoBody,
+		Header:     make(http.Header),
+	}
+}
@@ -0,0 +1,170 @@
+package schema_registry_helper
+
+import (
+	"context"
+	"fmt"
+)
+
+// DependencySchema describes one schema in a dependency graph handed
+// to CreateSchemaWithDependencies: a subject/schema pair plus the
+// names of the other schemas in the graph it imports (a Protobuf
+// `import` path or a JSON Schema `$ref` target).
+type DependencySchema struct {
+	Subject    string
+	Schema     string
+	SchemaType SchemaType
+	Imports    []string
+}
+
+// CreateSchemaWithDependencies registers a root schema and its
+// transitive dependencies in a single call. deps is a flat list
+// describing every schema in the dependency graph (including
+// intermediate ones); CreateSchemaWithDependencies builds a DAG from
+// their Imports, rejects cycles, registers leaves first, and finally
+// registers subject/schema as the root with a References slice
+// pointing at the registered dependencies.
+func (client *SchemaRegistryClient) CreateSchemaWithDependencies(subject, schema string,
+	schemaType SchemaType, deps []DependencySchema) (*Schema, error) {
+	return client.CreateSchemaWithDependenciesContext(context.Background(), subject, schema, schemaType, deps)
+}
+
+// CreateSchemaWithDependenciesContext is CreateSchemaWithDependencies with a caller-supplied context.
+func (client *SchemaRegistryClient) CreateSchemaWithDependenciesContext(ctx context.Context, subject, schema string,
+	schemaType SchemaType, deps []DependencySchema) (*Schema, error) {
+
+	order, err := topoSort(deps)
+	if err != nil {
+		return nil, err
+	}
+
+	bySubject := make(map[string]DependencySchema, len(deps))
+	for _, dep := range deps {
+		bySubject[dep.Subject] = dep
+	}
+
+	registered := make(map[string]Reference, len(deps))
+	for _, name := range order {
+		dep := bySubject[name]
+
+		var refs []Reference
+		for _, imp := range dep.Imports {
+			ref, ok := registered[imp]
+			if !ok {
+				return nil, fmt.Errorf("schema_registry_helper: dependency %q references unknown import %q", dep.Subject, imp)
+			}
+			refs = append(refs, ref)
+		}
+
+		registeredSchema, err := client.CreateSchemaContext(ctx, dep.Subject, dep.Schema, dep.SchemaType, false, refs...)
+		if err != nil {
+			return nil, fmt.Errorf("schema_registry_helper: failed to register dependency %q: %w", dep.Subject, err)
+		}
+
+		registered[dep.Subject] = Reference{
+			Name:    dep.Subject,
+			Subject: dep.Subject,
+			Version: registeredSchema.Version(),
+		}
+	}
+
+	// Build rootRefs in topo order (not map iteration order, which Go
+	// randomizes) so that two calls with the identical dependency set
+	// always produce the same references payload. Otherwise a
+	// registry comparing schema text for exact equality would treat
+	// differently-ordered-but-equivalent requests as distinct schemas.
+	rootRefs := make([]Reference, 0, len(order))
+	for _, name := range order {
+		rootRefs = append(rootRefs, registered[name])
+	}
+
+	return client.CreateSchemaContext(ctx, subject, schema, schemaType, false, rootRefs...)
+}
+
+// topoSort returns the subjects in deps ordered so that every
+// dependency appears before anything that imports it, or an error if
+// the import graph contains a cycle.
+func topoSort(deps []DependencySchema) ([]string, error) {
+	bySubject := make(map[string]DependencySchema, len(deps))
+	for _, dep := range deps {
+		bySubject[dep.Subject] = dep
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(deps))
+	var order []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("schema_registry_helper: dependency cycle detected at %q", name)
+		}
+		state[name] = visiting
+		for _, imp := range bySubject[name].Imports {
+			if _, ok := bySubject[imp]; !ok {
+				continue
+			}
+			if err := visit(imp); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		order = append(order, name)
+		return nil
+	}
+
+	for _, dep := range deps {
+		if err := visit(dep.Subject); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// ResolveReferences walks the reference graph of schemaID and returns
+// every schema reachable from it, keyed by subject, populating both
+// the id and subject caches along the way.
+func (client *SchemaRegistryClient) ResolveReferences(schemaID int) (map[string]*Schema, error) {
+	return client.ResolveReferencesContext(context.Background(), schemaID)
+}
+
+// ResolveReferencesContext is ResolveReferences with a caller-supplied context.
+func (client *SchemaRegistryClient) ResolveReferencesContext(ctx context.Context, schemaID int) (map[string]*Schema, error) {
+
+	root, err := client.GetSchemaContext(ctx, schemaID)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make(map[string]*Schema)
+	var walk func(refs []Reference) error
+	walk = func(refs []Reference) error {
+		for _, ref := range refs {
+			if _, ok := resolved[ref.Subject]; ok {
+				continue
+			}
+			schema, err := client.GetSchemaByVersionContext(ctx, ref.Subject, ref.Version, false)
+			if err != nil {
+				return err
+			}
+			resolved[ref.Subject] = schema
+			if err := walk(schema.References()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := walk(root.References()); err != nil {
+		return nil, err
+	}
+
+	return resolved, nil
+}
@@ -0,0 +1,187 @@
+package schema_registry_helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestTopoSortOrdersDependenciesBeforeDependents(t *testing.T) {
+	deps := []DependencySchema{
+		{Subject: "d", Imports: []string{"a", "b"}},
+		{Subject: "a", Imports: []string{"c"}},
+		{Subject: "b", Imports: []string{"c"}},
+		{Subject: "c"},
+	}
+
+	order, err := topoSort(deps)
+	if err != nil {
+		t.Fatalf("topoSort: %v", err)
+	}
+
+	pos := make(map[string]int, len(order))
+	for i, name := range order {
+		pos[name] = i
+	}
+	if pos["c"] > pos["a"] || pos["c"] > pos["b"] || pos["a"] > pos["d"] || pos["b"] > pos["d"] {
+		t.Errorf("topoSort did not order dependencies before dependents: %v", order)
+	}
+}
+
+func TestTopoSortDetectsCycle(t *testing.T) {
+	deps := []DependencySchema{
+		{Subject: "a", Imports: []string{"b"}},
+		{Subject: "b", Imports: []string{"a"}},
+	}
+	if _, err := topoSort(deps); err == nil {
+		t.Fatal("expected a dependency cycle error, got nil")
+	}
+}
+
+// recordingTransport wraps an http.RoundTripper and records the body
+// of every request POSTed to path, so tests can inspect exactly what
+// was sent to the registry.
+type recordingTransport struct {
+	next http.RoundTripper
+	path string
+
+	mu     sync.Mutex
+	bodies [][]byte
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodPost && req.URL.Path == t.path && req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err == nil {
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			t.mu.Lock()
+			t.bodies = append(t.bodies, body)
+			t.mu.Unlock()
+		}
+	}
+	return t.next.RoundTrip(req)
+}
+
+// TestCreateSchemaWithDependenciesRootRefsOrderIsDeterministic guards
+// against rootRefs being built from map iteration (which Go
+// randomizes), by registering the same dependency set repeatedly and
+// checking every request carried references in the same order.
+func TestCreateSchemaWithDependenciesRootRefsOrderIsDeterministic(t *testing.T) {
+	mux := http.NewServeMux()
+	var mu sync.Mutex
+	nextID := 1
+	lastBySubject := make(map[string]schemaResponse)
+	mux.HandleFunc("/subjects/", func(w http.ResponseWriter, r *http.Request) {
+		subject := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/subjects/"), "/", 2)[0]
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.Method == http.MethodPost {
+			var req schemaRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			resp := schemaResponse{ID: nextID, Version: 1, Schema: req.Schema, References: req.References}
+			nextID++
+			lastBySubject[subject] = resp
+			writeTestSchemaResponse(w, resp.ID, resp.Version, resp.Schema, resp.References)
+			return
+		}
+
+		// GET .../versions/latest, made by CreateSchemaContext right
+		// after registering.
+		resp, ok := lastBySubject[subject]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		writeTestSchemaResponse(w, resp.ID, resp.Version, resp.Schema, resp.References)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	transport := &recordingTransport{next: http.DefaultTransport, path: "/subjects/widget-value/versions"}
+	client := CreateSchemaRegistryClientWithOptions(server.URL, WithHTTPClient(&http.Client{Transport: transport}))
+	defer client.Close()
+
+	deps := []DependencySchema{
+		{Subject: "c", Schema: `{"type":"record","name":"C","fields":[]}`, SchemaType: Avro},
+		{Subject: "a", Schema: `{"type":"record","name":"A","fields":[]}`, SchemaType: Avro, Imports: []string{"c"}},
+		{Subject: "b", Schema: `{"type":"record","name":"B","fields":[]}`, SchemaType: Avro, Imports: []string{"c"}},
+		{Subject: "d", Schema: `{"type":"record","name":"D","fields":[]}`, SchemaType: Avro, Imports: []string{"a", "b"}},
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := client.CreateSchemaWithDependencies("widget",
+			`{"type":"record","name":"Widget","fields":[]}`, Avro, deps); err != nil {
+			t.Fatalf("CreateSchemaWithDependencies: %v", err)
+		}
+	}
+
+	if len(transport.bodies) != 5 {
+		t.Fatalf("expected 5 recorded root registration requests, got %d", len(transport.bodies))
+	}
+
+	var want []string
+	for i, body := range transport.bodies {
+		var req schemaRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Fatalf("unmarshal request %d: %v", i, err)
+		}
+		var names []string
+		for _, ref := range req.References {
+			names = append(names, ref.Name)
+		}
+		if i == 0 {
+			want = names
+			continue
+		}
+		if !reflect.DeepEqual(names, want) {
+			t.Errorf("request %d: references order %v, want %v (rootRefs order is not deterministic)", i, names, want)
+		}
+	}
+}
+
+// TestResolveReferencesContextWalksTransitively registers a
+// root -> a -> b reference chain and checks that b, two levels deep,
+// is still resolved.
+func TestResolveReferencesContextWalksTransitively(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/schemas/ids/1", func(w http.ResponseWriter, r *http.Request) {
+		writeTestSchemaResponse(w, 1, 0, `{"type":"record","name":"Root","fields":[]}`,
+			[]Reference{{Name: "a", Subject: "a", Version: 1}})
+	})
+	mux.HandleFunc("/subjects/a-value/versions/1", func(w http.ResponseWriter, r *http.Request) {
+		writeTestSchemaResponse(w, 2, 1, `{"type":"record","name":"A","fields":[]}`,
+			[]Reference{{Name: "b", Subject: "b", Version: 1}})
+	})
+	mux.HandleFunc("/subjects/b-value/versions/1", func(w http.ResponseWriter, r *http.Request) {
+		writeTestSchemaResponse(w, 3, 1, `{"type":"record","name":"B","fields":[]}`, nil)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := CreateSchemaRegistryClient(server.URL)
+	defer client.Close()
+
+	resolved, err := client.ResolveReferences(1)
+	if err != nil {
+		t.Fatalf("ResolveReferences: %v", err)
+	}
+	if _, ok := resolved["a"]; !ok {
+		t.Errorf("missing direct reference %q", "a")
+	}
+	if _, ok := resolved["b"]; !ok {
+		t.Errorf("missing transitive reference %q: walk is not recursing past the first level", "b")
+	}
+}
+
+func writeTestSchemaResponse(w http.ResponseWriter, id, version int, schema string, refs []Reference) {
+	w.Header().Set("Content-Type", contentType)
+	_ = json.NewEncoder(w).Encode(schemaResponse{ID: id, Version: version, Schema: schema, References: refs})
+}
@@ -2,15 +2,16 @@ package schema_registry_helper
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 )
 
@@ -23,14 +24,18 @@ import (
 // which in turn can be used to serialize and
 // deserialize data.
 type SchemaRegistryClient struct {
-	schemaRegistryURL      string
-	credentials            *credentials
-	httpClient             *http.Client
-	cachingEnabled         bool
-	idSchemaCache          map[int]*Schema
-	idSchemaCacheLock      sync.RWMutex
-	subjectSchemaCache     map[string]*Schema
-	subjectSchemaCacheLock sync.RWMutex
+	schemaRegistryURL  string
+	credentials        *credentials
+	bearerToken        string
+	tokenSource        TokenSource
+	httpClient         *http.Client
+	cachingEnabled     bool
+	idSchemaCache      Cache
+	subjectSchemaCache Cache
+	latestSchemaCache  Cache
+	latestCacheTTL     time.Duration
+	maxRetries         int
+	retryBase          time.Duration
 }
 
 // Schema references use the import statement of Protobuf and
@@ -45,9 +50,11 @@ type Reference struct {
 // Schema is a data structure that holds all
 // the relevant information about schemas.
 type Schema struct {
-	id      int
-	schema  string
-	version int
+	id         int
+	schema     string
+	schemaType SchemaType
+	version    int
+	references []Reference
 }
 
 // Used if we are connecting to Confluent Cloud
@@ -63,10 +70,12 @@ type schemaRequest struct {
 }
 
 type schemaResponse struct {
-	Subject string `json:"subject"`
-	Version int    `json:"version"`
-	Schema  string `json:"schema"`
-	ID      int    `json:"id"`
+	Subject    string      `json:"subject"`
+	Version    int         `json:"version"`
+	Schema     string      `json:"schema"`
+	SchemaType string      `json:"schemaType"`
+	ID         int         `json:"id"`
+	References []Reference `json:"references"`
 }
 
 type SchemaType string
@@ -75,6 +84,17 @@ func (s SchemaType) String() string {
 	return string(s)
 }
 
+// schemaTypeFromResponse converts the schemaType a registry response
+// reported into a SchemaType, defaulting to Avro when it's omitted
+// (Schema Registry's own default when a schema was registered before
+// schemaType existed, or without specifying one).
+func schemaTypeFromResponse(schemaType string) SchemaType {
+	if schemaType == "" {
+		return Avro
+	}
+	return SchemaType(schemaType)
+}
+
 const (
 	Protobuf         SchemaType = "PROTOBUF"
 	Avro             SchemaType = "AVRO"
@@ -93,26 +113,68 @@ var ErrNotFound = "404 Not Found"
 // using this client can retrieve data about schemas, which
 // in turn can be used to serialize and deserialize records.
 func CreateSchemaRegistryClient(schemaRegistryURL string) *SchemaRegistryClient {
-	return &SchemaRegistryClient{schemaRegistryURL: schemaRegistryURL,
+	const defaultLatestCacheTTL = 5 * time.Minute
+	client := &SchemaRegistryClient{schemaRegistryURL: schemaRegistryURL,
 		httpClient:         &http.Client{Timeout: 5 * time.Second},
 		cachingEnabled:     true,
-		idSchemaCache:      make(map[int]*Schema),
-		subjectSchemaCache: make(map[string]*Schema)}
+		idSchemaCache:      newMapCache(),
+		subjectSchemaCache: newMapCache(),
+		latestSchemaCache:  newTTLCache(defaultLatestCacheTTL),
+		latestCacheTTL:     defaultLatestCacheTTL,
+	}
+	runtime.SetFinalizer(client, func(c *SchemaRegistryClient) { c.Close() })
+	return client
+}
+
+// SetLatestCacheTTL changes how long a GetLatestSchema result is
+// served from cache before the next call refetches it from Schema
+// Registry. It replaces the existing latest-schema cache, discarding
+// anything already cached under the old TTL.
+func (client *SchemaRegistryClient) SetLatestCacheTTL(d time.Duration) {
+	if old, ok := client.latestSchemaCache.(*ttlCache); ok {
+		old.Close()
+	}
+	client.latestCacheTTL = d
+	client.latestSchemaCache = newTTLCache(d)
+}
+
+// ClearCache purges the id-indexed, subject-indexed, and
+// latest-schema caches.
+func (client *SchemaRegistryClient) ClearCache() {
+	client.idSchemaCache.Clear()
+	client.subjectSchemaCache.Clear()
+	client.latestSchemaCache.Clear()
+}
+
+// Close stops the background goroutine that sweeps expired entries
+// from the latest-schema cache. It is safe, but unnecessary, to call
+// more than once; the client is also closed automatically via a
+// finalizer if Close is never called explicitly.
+func (client *SchemaRegistryClient) Close() {
+	if c, ok := client.latestSchemaCache.(*ttlCache); ok {
+		c.Close()
+	}
 }
 
 // GetSchema gets the schema associated with the given id.
 func (client *SchemaRegistryClient) GetSchema(schemaID int) (*Schema, error) {
+	return client.GetSchemaContext(context.Background(), schemaID)
+}
+
+// GetSchemaContext is GetSchema with a caller-supplied context. The
+// context governs both the cache lookup and, on a cache miss, the
+// underlying HTTP request.
+func (client *SchemaRegistryClient) GetSchemaContext(ctx context.Context, schemaID int) (*Schema, error) {
 
 	if client.cachingEnabled {
-		client.idSchemaCacheLock.RLock()
-		cachedSchema := client.idSchemaCache[schemaID]
-		client.idSchemaCacheLock.RUnlock()
-		if cachedSchema != nil {
-			return cachedSchema, nil
+		if cached, ok, err := cacheGetContext(ctx, client.idSchemaCache, schemaID); err != nil {
+			return nil, err
+		} else if ok {
+			return cached.(*Schema), nil
 		}
 	}
 
-	resp, err := client.httpRequest("GET", fmt.Sprintf(schemaByID, schemaID), nil)
+	resp, err := client.httpRequestContext(ctx, "GET", fmt.Sprintf(schemaByID, schemaID), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -123,40 +185,69 @@ func (client *SchemaRegistryClient) GetSchema(schemaID int) (*Schema, error) {
 		return nil, err
 	}
 	var schema = &Schema{
-		id:     schemaID,
-		schema: schemaResp.Schema,
+		id:         schemaID,
+		schema:     schemaResp.Schema,
+		schemaType: schemaTypeFromResponse(schemaResp.SchemaType),
+		references: schemaResp.References,
 	}
 
 	if client.cachingEnabled {
-		client.idSchemaCacheLock.Lock()
-		client.idSchemaCache[schemaID] = schema
-		client.idSchemaCacheLock.Unlock()
+		client.idSchemaCache.Put(schemaID, schema)
 	}
 
 	return schema, nil
 }
 
 // GetLatestSchema gets the schema associated with the given subject.
-// The schema returned contains the last version for that subject.
+// The schema returned contains the last version for that subject. The
+// result is served from a short-lived TTL cache (see
+// SetLatestCacheTTL) rather than the subject/version cache, since a
+// subject can gain new versions at any time.
 func (client *SchemaRegistryClient) GetLatestSchema(subject string, isKey bool) (*Schema, error) {
+	return client.GetLatestSchemaContext(context.Background(), subject, isKey)
+}
 
-	// In order to ensure consistency, we need
-	// to temporarily disable caching to force
-	// the retrieval of the latest release from
-	// Schema Registry.
-	cachingEnabled := client.cachingEnabled
-	client.CachingEnabled(false)
-	schema, err := client.getVersion(subject, "latest", isKey)
-	client.CachingEnabled(cachingEnabled)
+// GetLatestSchemaContext is GetLatestSchema with a caller-supplied context.
+func (client *SchemaRegistryClient) GetLatestSchemaContext(ctx context.Context, subject string, isKey bool) (*Schema, error) {
 
-	return schema, err
+	concreteSubject := getConcreteSubject(subject, isKey)
+
+	if client.cachingEnabled {
+		if cached, ok, err := cacheGetContext(ctx, client.latestSchemaCache, concreteSubject); err != nil {
+			return nil, err
+		} else if ok {
+			return cached.(*Schema), nil
+		}
+	}
+
+	schema, err := client.getVersionUncachedContext(ctx, concreteSubject, "latest")
+	if err != nil {
+		return nil, err
+	}
+
+	if client.cachingEnabled {
+		client.latestSchemaCache.Put(concreteSubject, schema)
+	}
+
+	return schema, nil
 }
 
 // GetSchemaVersions returns a list of versions from a given subject.
-func (client *SchemaRegistryClient) GetSchemaVersions(subject string, isKey bool) ([]int, error) {
+// When includeDeleted is true, versions that have been soft-deleted
+// are included in the result.
+func (client *SchemaRegistryClient) GetSchemaVersions(subject string, isKey bool, includeDeleted bool) ([]int, error) {
+	return client.GetSchemaVersionsContext(context.Background(), subject, isKey, includeDeleted)
+}
+
+// GetSchemaVersionsContext is GetSchemaVersions with a caller-supplied context.
+func (client *SchemaRegistryClient) GetSchemaVersionsContext(ctx context.Context, subject string, isKey bool, includeDeleted bool) ([]int, error) {
 
 	concreteSubject := getConcreteSubject(subject, isKey)
-	resp, err := client.httpRequest("GET", fmt.Sprintf(subjectVersions, concreteSubject), nil)
+	uri := fmt.Sprintf(subjectVersions, concreteSubject)
+	if includeDeleted {
+		uri += "?deleted=true"
+	}
+	resp, err := client.httpRequestContext(ctx, "GET", uri, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -173,7 +264,12 @@ func (client *SchemaRegistryClient) GetSchemaVersions(subject string, isKey bool
 // GetSchemaByVersion gets the schema associated with the given subject.
 // The schema returned contains the version specified as a parameter.
 func (client *SchemaRegistryClient) GetSchemaByVersion(subject string, version int, isKey bool) (*Schema, error) {
-	return client.getVersion(subject, strconv.Itoa(version), isKey)
+	return client.GetSchemaByVersionContext(context.Background(), subject, version, isKey)
+}
+
+// GetSchemaByVersionContext is GetSchemaByVersion with a caller-supplied context.
+func (client *SchemaRegistryClient) GetSchemaByVersionContext(ctx context.Context, subject string, version int, isKey bool) (*Schema, error) {
+	return client.getVersionContext(ctx, subject, strconv.Itoa(version), isKey)
 }
 
 // CheckSchema creates a new schema in Schema Registry and associates
@@ -181,6 +277,12 @@ func (client *SchemaRegistryClient) GetSchemaByVersion(subject string, version i
 // all its associated information.
 func (client *SchemaRegistryClient) CheckSchema(subject, schema string,
 	schemaType SchemaType, isKey bool, references ...Reference) (*schemaResponse, error) {
+	return client.CheckSchemaContext(context.Background(), subject, schema, schemaType, isKey, references...)
+}
+
+// CheckSchemaContext is CheckSchema with a caller-supplied context.
+func (client *SchemaRegistryClient) CheckSchemaContext(ctx context.Context, subject, schema string,
+	schemaType SchemaType, isKey bool, references ...Reference) (*schemaResponse, error) {
 
 	concreteSubject := getConcreteSubject(subject, isKey)
 	payload, err := createPayload(schema, schemaType, references)
@@ -188,7 +290,7 @@ func (client *SchemaRegistryClient) CheckSchema(subject, schema string,
 		return nil, err
 	}
 
-	resp, err := client.httpRequest("POST", fmt.Sprintf(subjectCheck, concreteSubject), payload)
+	resp, err := client.httpRequestContext(ctx, "POST", fmt.Sprintf(subjectCheck, concreteSubject), payload)
 	if err != nil {
 		return nil, err
 	}
@@ -208,6 +310,12 @@ func (client *SchemaRegistryClient) CheckSchema(subject, schema string,
 // all its associated information.
 func (client *SchemaRegistryClient) CreateSchema(subject, schema string,
 	schemaType SchemaType, isKey bool, references ...Reference) (*Schema, error) {
+	return client.CreateSchemaContext(context.Background(), subject, schema, schemaType, isKey, references...)
+}
+
+// CreateSchemaContext is CreateSchema with a caller-supplied context.
+func (client *SchemaRegistryClient) CreateSchemaContext(ctx context.Context, subject, schema string,
+	schemaType SchemaType, isKey bool, references ...Reference) (*Schema, error) {
 
 	concreteSubject := getConcreteSubject(subject, isKey)
 	payload, err := createPayload(schema, schemaType, references)
@@ -215,7 +323,7 @@ func (client *SchemaRegistryClient) CreateSchema(subject, schema string,
 		return nil, err
 	}
 
-	resp, err := client.httpRequest("POST", fmt.Sprintf(subjectVersions, concreteSubject), payload)
+	resp, err := client.httpRequestContext(ctx, "POST", fmt.Sprintf(subjectVersions, concreteSubject), payload)
 	if err != nil {
 		return nil, err
 	}
@@ -232,7 +340,11 @@ func (client *SchemaRegistryClient) CreateSchema(subject, schema string,
 	// this logic strongly relies on the idempotent guarantees
 	// from Schema Registry, as well as in the best practice
 	// that schemas don't change very often.
-	newSchema, err := client.GetLatestSchema(subject, isKey)
+	//
+	// We just wrote a new version, so the latest-schema cache
+	// entry (if any) is now stale; drop it before refetching.
+	client.latestSchemaCache.Delete(concreteSubject)
+	newSchema, err := client.GetLatestSchemaContext(ctx, subject, isKey)
 	if err != nil {
 		return nil, err
 	}
@@ -242,14 +354,10 @@ func (client *SchemaRegistryClient) CreateSchema(subject, schema string,
 		// Update the subject-2-schema cache
 		cacheKey := cacheKey(concreteSubject,
 			strconv.Itoa(newSchema.version))
-		client.subjectSchemaCacheLock.Lock()
-		client.subjectSchemaCache[cacheKey] = newSchema
-		client.subjectSchemaCacheLock.Unlock()
+		client.subjectSchemaCache.Put(cacheKey, newSchema)
 
 		// Update the id-2-schema cache
-		client.idSchemaCacheLock.Lock()
-		client.idSchemaCache[newSchema.id] = newSchema
-		client.idSchemaCacheLock.Unlock()
+		client.idSchemaCache.Put(newSchema.id, newSchema)
 
 	}
 
@@ -282,77 +390,174 @@ func (client *SchemaRegistryClient) CachingEnabled(value bool) {
 
 func (client *SchemaRegistryClient) getVersion(subject string,
 	version string, isKey bool) (*Schema, error) {
+	return client.getVersionContext(context.Background(), subject, version, isKey)
+}
+
+func (client *SchemaRegistryClient) getVersionContext(ctx context.Context, subject string,
+	version string, isKey bool) (*Schema, error) {
 
 	concreteSubject := getConcreteSubject(subject, isKey)
 
 	if client.cachingEnabled {
 		cacheKey := cacheKey(concreteSubject, version)
-		client.subjectSchemaCacheLock.RLock()
-		cachedResult := client.subjectSchemaCache[cacheKey]
-		client.subjectSchemaCacheLock.RUnlock()
-		if cachedResult != nil {
-			return cachedResult, nil
+		if cachedResult, ok, err := cacheGetContext(ctx, client.subjectSchemaCache, cacheKey); err != nil {
+			return nil, err
+		} else if ok {
+			return cachedResult.(*Schema), nil
 		}
 	}
 
-	resp, err := client.httpRequest("GET", fmt.Sprintf(subjectByVersion, concreteSubject, version), nil)
-	if err != nil {
-		return nil, err
-	}
-
-	schemaResp := new(schemaResponse)
-	err = json.Unmarshal(resp, &schemaResp)
+	schema, err := client.getVersionUncachedContext(ctx, concreteSubject, version)
 	if err != nil {
 		return nil, err
 	}
-	var schema = &Schema{
-		id:      schemaResp.ID,
-		schema:  schemaResp.Schema,
-		version: schemaResp.Version,
-	}
 
 	if client.cachingEnabled {
 
 		// Update the subject-2-schema cache
 		cacheKey := cacheKey(concreteSubject, version)
-		client.subjectSchemaCacheLock.Lock()
-		client.subjectSchemaCache[cacheKey] = schema
-		client.subjectSchemaCacheLock.Unlock()
+		client.subjectSchemaCache.Put(cacheKey, schema)
 
 		// Update the id-2-schema cache
-		client.idSchemaCacheLock.Lock()
-		client.idSchemaCache[schema.id] = schema
-		client.idSchemaCacheLock.Unlock()
+		client.idSchemaCache.Put(schema.id, schema)
 
 	}
 
 	return schema, nil
 }
 
-func (client *SchemaRegistryClient) httpRequest(method, uri string, payload io.Reader) ([]byte, error) {
+// getVersionUncachedContext fetches a subject/version pair directly
+// from Schema Registry, bypassing both the subject-version and latest
+// caches. Callers are responsible for caching the result if desired.
+func (client *SchemaRegistryClient) getVersionUncachedContext(ctx context.Context, concreteSubject string, version string) (*Schema, error) {
 
-	url := fmt.Sprintf("%s%s", client.schemaRegistryURL, uri)
-	req, err := http.NewRequest(method, url, payload)
+	resp, err := client.httpRequestContext(ctx, "GET", fmt.Sprintf(subjectByVersion, concreteSubject, version), nil)
 	if err != nil {
 		return nil, err
 	}
-	if client.credentials != nil {
-		req.SetBasicAuth(client.credentials.username, client.credentials.password)
-	}
-	req.Header.Set("Content-Type", contentType)
-	resp, err := client.httpClient.Do(req)
+
+	schemaResp := new(schemaResponse)
+	err = json.Unmarshal(resp, &schemaResp)
 	if err != nil {
 		return nil, err
 	}
+	return &Schema{
+		id:         schemaResp.ID,
+		schema:     schemaResp.Schema,
+		schemaType: schemaTypeFromResponse(schemaResp.SchemaType),
+		version:    schemaResp.Version,
+		references: schemaResp.References,
+	}, nil
+}
+
+func (client *SchemaRegistryClient) httpRequest(method, uri string, payload io.Reader) ([]byte, error) {
+	return client.httpRequestContext(context.Background(), method, uri, payload)
+}
+
+func (client *SchemaRegistryClient) httpRequestContext(ctx context.Context, method, uri string, payload io.Reader) ([]byte, error) {
+
+	var body []byte
+	if payload != nil {
+		var err error
+		body, err = ioutil.ReadAll(payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	url := fmt.Sprintf("%s%s", client.schemaRegistryURL, uri)
+
+	var lastErr error
+	var retryAfter string
+	for attempt := 0; attempt <= client.maxRetries; attempt++ {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if attempt > 0 {
+			delay := retryDelay(client.retryBase, attempt, retryAfter)
+			retryAfter = ""
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		var reqBody io.Reader
+		if body != nil {
+			reqBody = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+		if err != nil {
+			return nil, err
+		}
+		if err := client.authenticate(req); err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", contentType)
+
+		resp, err := client.httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			retryErr := createError(resp)
+			resp.Body.Close()
+			if attempt < client.maxRetries && isRetryableStatus(resp.StatusCode) {
+				lastErr = retryErr
+				retryAfter = resp.Header.Get("Retry-After")
+				continue
+			}
+			return nil, retryErr
+		}
 
-	if resp != nil {
 		defer resp.Body.Close()
+		return ioutil.ReadAll(resp.Body)
+	}
+
+	return nil, lastErr
+}
+
+// authenticate applies whichever authentication method is configured
+// on client, in order of precedence: TokenSource, static bearer
+// token, then basic auth.
+func (client *SchemaRegistryClient) authenticate(req *http.Request) error {
+	if client.tokenSource != nil {
+		token, err := client.tokenSource.Token(req.Context())
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+	if client.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+client.bearerToken)
+		return nil
 	}
-	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		return nil, createError(resp)
+	if client.credentials != nil {
+		req.SetBasicAuth(client.credentials.username, client.credentials.password)
 	}
+	return nil
+}
 
-	return ioutil.ReadAll(resp.Body)
+func isRetryableStatus(status int) bool {
+	return status == http.StatusRequestTimeout ||
+		status == http.StatusTooManyRequests ||
+		status >= 500
+}
+
+// retryDelay computes the backoff before the given retry attempt
+// (1-indexed), honoring a Retry-After header from the prior response
+// when one was set.
+func retryDelay(base time.Duration, attempt int, retryAfter string) time.Duration {
+	if retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return base * time.Duration(1<<uint(attempt-1))
 }
 
 // ID ensures access to ID
@@ -370,6 +575,17 @@ func (schema *Schema) Version() int {
 	return schema.version
 }
 
+// SchemaType ensures access to the schema's registered type, as
+// reported by Schema Registry.
+func (schema *Schema) SchemaType() SchemaType {
+	return schema.schemaType
+}
+
+// References ensures access to the schema's references
+func (schema *Schema) References() []Reference {
+	return schema.references
+}
+
 func cacheKey(subject string, version string) string {
 	return fmt.Sprintf("%s-%s", subject, version)
 }
@@ -0,0 +1,404 @@
+package schema_registry_helper
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/hamba/avro/v2"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoprint"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+)
+
+// magicByte is prepended to every message produced by Serialize, per
+// the Confluent wire format: magic byte + 4-byte big-endian schema ID
+// + payload.
+const magicByte byte = 0x0
+
+// SubjectNameStrategy computes the subject under which the key or
+// value schema for a topic is registered. Implementations are given
+// the topic, whether this is the key or the value, and the schema
+// being registered (so strategies that depend on the record name can
+// inspect it).
+type SubjectNameStrategy func(topic string, isKey bool, schema string) string
+
+// TopicNameStrategy is Schema Registry's default: "<topic>-key" or
+// "<topic>-value".
+func TopicNameStrategy(topic string, isKey bool, schema string) string {
+	return getConcreteSubject(topic, isKey)
+}
+
+// RecordNameStrategy subjects schemas by the fully-qualified record
+// name, independent of the topic they are produced to. This allows
+// multiple record types to share a single topic.
+func RecordNameStrategy(topic string, isKey bool, schema string) string {
+	return recordName(schema)
+}
+
+// TopicRecordNameStrategy combines the topic and the fully-qualified
+// record name, allowing a topic to carry multiple record types while
+// still scoping each subject to that topic.
+func TopicRecordNameStrategy(topic string, isKey bool, schema string) string {
+	return fmt.Sprintf("%s-%s", topic, recordName(schema))
+}
+
+// recordName extracts the fully-qualified name of an Avro record
+// ("namespace.name") from its schema. Schemas for which no name can
+// be determined (e.g. Protobuf, JSON Schema) are returned unchanged.
+func recordName(schema string) string {
+	var parsed struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	}
+	if err := json.Unmarshal([]byte(schema), &parsed); err != nil || parsed.Name == "" {
+		return schema
+	}
+	if parsed.Namespace == "" {
+		return parsed.Name
+	}
+	return fmt.Sprintf("%s.%s", parsed.Namespace, parsed.Name)
+}
+
+// Serde serializes and deserializes record values into the Confluent
+// wire format, resolving and caching schemas through a
+// SchemaRegistryClient. The zero value is not usable; create one with
+// NewSerde.
+type Serde struct {
+	client       *SchemaRegistryClient
+	subjectName  SubjectNameStrategy
+	protoFactory map[string]func() proto.Message
+}
+
+// NewSerde creates a Serde backed by the given client. It defaults to
+// TopicNameStrategy for subject naming; call WithSubjectNameStrategy
+// to override it.
+func NewSerde(client *SchemaRegistryClient) *Serde {
+	return &Serde{
+		client:       client,
+		subjectName:  TopicNameStrategy,
+		protoFactory: make(map[string]func() proto.Message),
+	}
+}
+
+// WithSubjectNameStrategy overrides the strategy used to derive a
+// subject from a topic and schema.
+func (s *Serde) WithSubjectNameStrategy(strategy SubjectNameStrategy) *Serde {
+	s.subjectName = strategy
+	return s
+}
+
+// RegisterProtoMessage associates a concrete proto.Message type with
+// a subject, so Deserialize knows what to unmarshal Protobuf payloads
+// for that subject into.
+func (s *Serde) RegisterProtoMessage(subject string, factory func() proto.Message) {
+	s.protoFactory[subject] = factory
+}
+
+// Serialize encodes value according to the schema registered (or
+// registered on demand) for the topic, and frames it with the
+// Confluent wire format.
+func (s *Serde) Serialize(topic string, isKey bool, schemaType SchemaType, value interface{}) ([]byte, error) {
+	var payload []byte
+	var schemaStr string
+	var err error
+
+	switch schemaType {
+	case Avro:
+		avroSchema, ok := value.(avroEncodable)
+		if !ok {
+			return nil, fmt.Errorf("schema_registry_helper: value does not implement avroEncodable for Avro serialization")
+		}
+		schemaStr = avroSchema.Schema()
+		var avroSchemaParsed avro.Schema
+		avroSchemaParsed, err = parseAvroSchema(schemaStr)
+		if err != nil {
+			return nil, err
+		}
+		payload, err = avro.Marshal(avroSchemaParsed, value)
+	case Protobuf:
+		msg, ok := value.(proto.Message)
+		if !ok {
+			return nil, fmt.Errorf("schema_registry_helper: value does not implement proto.Message for Protobuf serialization")
+		}
+		schemaStr, err = protoFileSchema(msg)
+		if err != nil {
+			return nil, err
+		}
+		payload, err = proto.Marshal(msg)
+	case Json:
+		payload, err = json.Marshal(value)
+		if err == nil {
+			schemaStr, err = jsonSchemaFor(value)
+		}
+	default:
+		return nil, fmt.Errorf("schema_registry_helper: unsupported schema type %q", schemaType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	subject := s.subjectName(topic, isKey, schemaStr)
+	// subject is already the full name; CreateSchema would suffix it again.
+	schemaPayload, err := createPayload(schemaStr, schemaType, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.httpRequest("POST", fmt.Sprintf(subjectVersions, subject), schemaPayload)
+	if err != nil {
+		return nil, err
+	}
+	var registered schemaResponse
+	if err := json.Unmarshal(resp, &registered); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(magicByte)
+	var idBytes [4]byte
+	binary.BigEndian.PutUint32(idBytes[:], uint32(registered.ID))
+	buf.Write(idBytes[:])
+
+	if schemaType == Protobuf {
+		// A single top-level message is optimized to one zero byte,
+		// meaning "no index list, implicitly message index 0". Since
+		// this package only ever encodes the root message of a file,
+		// that optimization always applies here.
+		buf.WriteByte(0x00)
+	}
+
+	buf.Write(payload)
+	return buf.Bytes(), nil
+}
+
+// Deserialize decodes data produced by Serialize (or by any producer
+// following the Confluent wire format), resolving the schema by the
+// ID embedded in the payload.
+func (s *Serde) Deserialize(data []byte) (interface{}, error) {
+	if len(data) < 5 || data[0] != magicByte {
+		return nil, fmt.Errorf("schema_registry_helper: payload is missing the Confluent magic byte framing")
+	}
+	schemaID := int(binary.BigEndian.Uint32(data[1:5]))
+	schema, err := s.client.GetSchema(schemaID)
+	if err != nil {
+		return nil, err
+	}
+	body := data[5:]
+
+	switch schemaType := schema.SchemaType(); schemaType {
+	case Avro:
+		parsed, err := s.parseAvroSchemaForDecode(schemaID, schema)
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		if err := avro.Unmarshal(parsed, body, &value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	case Protobuf:
+		body, err = consumeMessageIndex(body)
+		if err != nil {
+			return nil, err
+		}
+		factory, ok := s.protoFactory[schema.Schema()]
+		if !ok {
+			return body, nil
+		}
+		msg := factory()
+		if err := proto.Unmarshal(body, msg); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	default:
+		var value interface{}
+		if err := json.Unmarshal(body, &value); err != nil {
+			return nil, err
+		}
+		compiled, err := jsonschema.CompileString("schema.json", schema.Schema())
+		if err != nil {
+			return nil, fmt.Errorf("schema_registry_helper: invalid JSON schema for id %d: %w", schemaID, err)
+		}
+		if err := compiled.Validate(value); err != nil {
+			return nil, fmt.Errorf("schema_registry_helper: payload does not satisfy registered JSON schema: %w", err)
+		}
+		return value, nil
+	}
+}
+
+// avroEncodable lets callers pass values that know their own Avro
+// schema, since there is no reflection-based way to recover it from
+// a plain Go value.
+type avroEncodable interface {
+	Schema() string
+}
+
+// parseAvroSchema parses an Avro schema with no external named-type
+// references.
+func parseAvroSchema(schema string) (avro.Schema, error) {
+	parsed, err := avro.Parse(schema)
+	if err != nil {
+		return nil, fmt.Errorf("schema_registry_helper: failed to parse Avro schema: %w", err)
+	}
+	return parsed, nil
+}
+
+// parseAvroSchemaForDecode parses schema.Schema(), first resolving
+// and parsing any schemas it references (transitively) into a shared
+// cache so that named types the root schema refers to but doesn't
+// define are already known by the time it is parsed. GetSchema only
+// ever returns a schema's own text, so a root schema registered with
+// references cannot be parsed standalone.
+func (s *Serde) parseAvroSchemaForDecode(schemaID int, schema *Schema) (avro.Schema, error) {
+	if len(schema.References()) == 0 {
+		return parseAvroSchema(schema.Schema())
+	}
+
+	deps, err := s.client.ResolveReferences(schemaID)
+	if err != nil {
+		return nil, fmt.Errorf("schema_registry_helper: failed to resolve Avro schema references for id %d: %w", schemaID, err)
+	}
+
+	cache := new(avro.SchemaCache)
+	for _, dep := range deps {
+		if _, err := avro.ParseWithCache(dep.Schema(), "", cache); err != nil {
+			return nil, fmt.Errorf("schema_registry_helper: failed to parse referenced Avro schema %q: %w", dep.Schema(), err)
+		}
+	}
+
+	parsed, err := avro.ParseWithCache(schema.Schema(), "", cache)
+	if err != nil {
+		return nil, fmt.Errorf("schema_registry_helper: failed to parse Avro schema for id %d: %w", schemaID, err)
+	}
+	return parsed, nil
+}
+
+// protoFileSchema renders the .proto IDL text for the file msg was
+// generated from, which is what Schema Registry expects as a
+// Protobuf schema's text (not the import path of the file).
+func protoFileSchema(msg proto.Message) (string, error) {
+	fileProto := protodesc.ToFileDescriptorProto(msg.ProtoReflect().Descriptor().ParentFile())
+	fd, err := desc.CreateFileDescriptor(fileProto)
+	if err != nil {
+		return "", fmt.Errorf("schema_registry_helper: failed to build file descriptor for %q: %w", fileProto.GetName(), err)
+	}
+	printer := protoprint.Printer{}
+	text, err := printer.PrintProtoToString(fd)
+	if err != nil {
+		return "", fmt.Errorf("schema_registry_helper: failed to render Protobuf schema text for %q: %w", fileProto.GetName(), err)
+	}
+	return text, nil
+}
+
+// consumeMessageIndex strips the Confluent Protobuf message-index
+// prefix from body and returns what follows it. A single top-level
+// message is optimized to one zero byte (size 0, meaning the index
+// list is implicitly [0]); the general form is a size varint followed
+// by that many index varints.
+func consumeMessageIndex(body []byte) ([]byte, error) {
+	size, n := protowire.ConsumeVarint(body)
+	if n <= 0 {
+		return nil, fmt.Errorf("schema_registry_helper: malformed Protobuf message-index prefix")
+	}
+	body = body[n:]
+	for i := uint64(0); i < size; i++ {
+		_, n := protowire.ConsumeVarint(body)
+		if n <= 0 {
+			return nil, fmt.Errorf("schema_registry_helper: malformed Protobuf message-index prefix")
+		}
+		body = body[n:]
+	}
+	return body, nil
+}
+
+// jsonEncodable lets callers pass values that know their own JSON
+// Schema, mirroring avroEncodable for Avro. Values that don't
+// implement it get a schema inferred from their shape by reflection.
+type jsonEncodable interface {
+	JSONSchema() string
+}
+
+func jsonSchemaFor(value interface{}) (string, error) {
+	var schema map[string]interface{}
+	if encodable, ok := value.(jsonEncodable); ok {
+		if err := json.Unmarshal([]byte(encodable.JSONSchema()), &schema); err != nil {
+			return "", fmt.Errorf("schema_registry_helper: invalid JSON schema from %T.JSONSchema(): %w", value, err)
+		}
+	} else {
+		schema = inferJSONSchema(reflect.ValueOf(value))
+	}
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return "", err
+	}
+	return string(schemaBytes), nil
+}
+
+// inferJSONSchema derives a JSON Schema fragment describing the shape
+// of v, by reflecting over its concrete Go type. It is necessarily
+// approximate (e.g. it can't tell an optional field from a required
+// one), but it reflects the actual value instead of a fixed
+// placeholder.
+func inferJSONSchema(v reflect.Value) map[string]interface{} {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return map[string]interface{}{}
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		items := map[string]interface{}{}
+		if v.Len() > 0 {
+			items = inferJSONSchema(v.Index(0))
+		}
+		return map[string]interface{}{"type": "array", "items": items}
+	case reflect.Map:
+		properties := map[string]interface{}{}
+		for _, key := range v.MapKeys() {
+			properties[fmt.Sprint(key.Interface())] = inferJSONSchema(v.MapIndex(key))
+		}
+		return map[string]interface{}{"type": "object", "properties": properties}
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name := field.Name
+			if tag := field.Tag.Get("json"); tag != "" {
+				tagName := strings.Split(tag, ",")[0]
+				if tagName == "-" {
+					continue
+				}
+				if tagName != "" {
+					name = tagName
+				}
+			}
+			properties[name] = inferJSONSchema(v.Field(i))
+		}
+		return map[string]interface{}{"type": "object", "properties": properties}
+	default:
+		return map[string]interface{}{}
+	}
+}
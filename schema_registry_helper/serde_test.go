@@ -0,0 +1,155 @@
+package schema_registry_helper
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/ssh2go/schema-registry-helper/schema_registry_helper/srtest"
+)
+
+func TestConsumeMessageIndexSingleMessageOptimization(t *testing.T) {
+	body := append([]byte{0x00}, []byte("payload")...)
+	rest, err := consumeMessageIndex(body)
+	if err != nil {
+		t.Fatalf("consumeMessageIndex: %v", err)
+	}
+	if string(rest) != "payload" {
+		t.Errorf("got %q, want %q", rest, "payload")
+	}
+}
+
+func TestConsumeMessageIndexMultipleIndices(t *testing.T) {
+	var framed []byte
+	framed = protowire.AppendVarint(framed, 2)
+	framed = protowire.AppendVarint(framed, 2)
+	framed = protowire.AppendVarint(framed, 5)
+	framed = append(framed, []byte("payload")...)
+
+	rest, err := consumeMessageIndex(framed)
+	if err != nil {
+		t.Fatalf("consumeMessageIndex: %v", err)
+	}
+	if string(rest) != "payload" {
+		t.Errorf("got %q, want %q", rest, "payload")
+	}
+}
+
+func TestDeserializeAvroReturnsErrorInsteadOfPanicOnMalformedSchema(t *testing.T) {
+	fake := srtest.NewFakeRegistryServer()
+	defer fake.Close()
+	fake.Seed("widget-value", `{"type":"record","name":"Bad","fields":123}`, "AVRO")
+
+	client := CreateSchemaRegistryClient(fake.URL)
+	defer client.Close()
+	serde := NewSerde(client)
+
+	data := append([]byte{magicByte, 0, 0, 0, 1}, []byte("payload")...)
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("Deserialize panicked instead of returning an error: %v", r)
+		}
+	}()
+	if _, err := serde.Deserialize(data); err == nil {
+		t.Fatal("expected an error for an unparseable Avro schema, got nil")
+	}
+}
+
+// TestDeserializeUsesRegistrySchemaTypeNotSniffing guards against
+// dispatching on a heuristic sniff of the schema text: a top-level
+// Avro enum (or union) has no "type":"record" field, so a sniffer
+// that only recognizes that shape misclassifies it as JSON or
+// Protobuf. Deserialize must instead trust the schemaType the
+// registry reports for the schema.
+func TestDeserializeUsesRegistrySchemaTypeNotSniffing(t *testing.T) {
+	fake := srtest.NewFakeRegistryServer()
+	defer fake.Close()
+	id, _ := fake.Seed("widget-value", `{"type":"enum","name":"Color","symbols":["RED","GREEN"]}`, "AVRO")
+
+	client := CreateSchemaRegistryClient(fake.URL)
+	defer client.Close()
+	serde := NewSerde(client)
+
+	var idBytes [4]byte
+	for i := 3; i >= 0; i-- {
+		idBytes[i] = byte(id)
+		id >>= 8
+	}
+	avroEncodedRed := []byte{0x00}
+	data := append(append([]byte{magicByte}, idBytes[:]...), avroEncodedRed...)
+
+	value, err := serde.Deserialize(data)
+	if err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if value != "RED" {
+		t.Errorf("got %v, want %q", value, "RED")
+	}
+}
+
+func TestJSONSchemaForInfersArrayType(t *testing.T) {
+	fake := srtest.NewFakeRegistryServer()
+	defer fake.Close()
+	client := CreateSchemaRegistryClient(fake.URL)
+	defer client.Close()
+	serde := NewSerde(client)
+
+	data, err := serde.Serialize("widget", false, Json, []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+
+	value, err := serde.Deserialize(data)
+	if err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+
+	got, ok := value.([]interface{})
+	if !ok {
+		t.Fatalf("got %T, want []interface{}", value)
+	}
+	if len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("got %v, want [a b]", got)
+	}
+}
+
+func TestProtoFileSchemaRendersActualIDL(t *testing.T) {
+	fdProto := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("widget.proto"),
+		Syntax:  proto.String("proto3"),
+		Package: proto.String("widget"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Widget"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:     proto.String("id"),
+						Number:   proto.Int32(1),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						JsonName: proto.String("id"),
+					},
+				},
+			},
+		},
+	}
+	fd, err := protodesc.NewFile(fdProto, nil)
+	if err != nil {
+		t.Fatalf("protodesc.NewFile: %v", err)
+	}
+	msg := dynamicpb.NewMessage(fd.Messages().Get(0))
+
+	schema, err := protoFileSchema(msg)
+	if err != nil {
+		t.Fatalf("protoFileSchema: %v", err)
+	}
+	if !strings.Contains(schema, "message Widget") {
+		t.Errorf("expected rendered IDL containing %q, got the raw result %q (likely still returning a filename)", "message Widget", schema)
+	}
+}
@@ -0,0 +1,351 @@
+// Package srtest provides an in-process fake Schema Registry, so that
+// packages depending on schema_registry_helper can be exercised in
+// unit tests without a real Schema Registry or Kafka cluster.
+package srtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+)
+
+type storedSchema struct {
+	id         int
+	version    int
+	schema     string
+	schemaType string
+	deleted    bool
+}
+
+// FakeRegistryServer is an httptest-backed double for Confluent
+// Schema Registry's REST API. It implements just enough of the
+// surface for schema_registry_helper.SchemaRegistryClient to operate
+// against: schema registration/lookup by id, subject/version
+// lookups, deletion, and compatibility checks that always succeed.
+type FakeRegistryServer struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	nextID        int
+	bySubject     map[string][]*storedSchema
+	byID          map[int]*storedSchema
+	compatibility map[string]string
+}
+
+// NewFakeRegistryServer starts an in-process fake registry. Callers
+// should pass server.URL to schema_registry_helper.CreateSchemaRegistryClient
+// and call Close when finished.
+func NewFakeRegistryServer() *FakeRegistryServer {
+	f := &FakeRegistryServer{
+		nextID:        1,
+		bySubject:     make(map[string][]*storedSchema),
+		byID:          make(map[int]*storedSchema),
+		compatibility: make(map[string]string),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/schemas/ids/", f.handleSchemaByID)
+	mux.HandleFunc("/subjects/", f.handleSubjects)
+	mux.HandleFunc("/subjects", f.handleListSubjects)
+	mux.HandleFunc("/compatibility/subjects/", f.handleCompatibility)
+	mux.HandleFunc("/config/", f.handleSubjectConfig)
+	mux.HandleFunc("/config", f.handleGlobalConfig)
+	f.Server = httptest.NewServer(mux)
+	return f
+}
+
+// Seed pre-populates the store with a schema for subject, returning
+// the id and version it was assigned. It behaves like a POST to
+// /subjects/{subject}/versions: an identical, already-registered
+// schema is returned unchanged rather than duplicated.
+func (f *FakeRegistryServer) Seed(subject, schema, schemaType string) (id int, version int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.register(subject, schema, schemaType)
+}
+
+func (f *FakeRegistryServer) register(subject, schema, schemaType string) (int, int) {
+	for _, existing := range f.bySubject[subject] {
+		if existing.schema == schema && !existing.deleted {
+			return existing.id, existing.version
+		}
+	}
+	id := f.nextID
+	f.nextID++
+	version := len(f.bySubject[subject]) + 1
+	s := &storedSchema{id: id, version: version, schema: schema, schemaType: schemaType}
+	f.bySubject[subject] = append(f.bySubject[subject], s)
+	f.byID[id] = s
+	return id, version
+}
+
+func (f *FakeRegistryServer) handleSchemaByID(w http.ResponseWriter, r *http.Request) {
+	idStr := r.URL.Path[len("/schemas/ids/"):]
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, 42201, "invalid id")
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.byID[id]
+	if !ok || (s.deleted && r.URL.Query().Get("deleted") != "true") {
+		writeError(w, http.StatusNotFound, 40403, "Schema not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"schema":     s.schema,
+		"schemaType": s.schemaType,
+	})
+}
+
+func (f *FakeRegistryServer) handleListSubjects(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, 405, "method not allowed")
+		return
+	}
+	includeDeleted := r.URL.Query().Get("deleted") == "true"
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var subjects []string
+	for subject, versions := range f.bySubject {
+		if includeDeleted || !allDeleted(versions) {
+			subjects = append(subjects, subject)
+		}
+	}
+	writeJSON(w, http.StatusOK, subjects)
+}
+
+func allDeleted(versions []*storedSchema) bool {
+	for _, v := range versions {
+		if !v.deleted {
+			return false
+		}
+	}
+	return true
+}
+
+// handleSubjects serves:
+//
+//	GET/POST /subjects/{subject}
+//	GET/POST /subjects/{subject}/versions
+//	GET      /subjects/{subject}/versions/{version}
+//	DELETE   /subjects/{subject}
+//	DELETE   /subjects/{subject}/versions/{version}
+func (f *FakeRegistryServer) handleSubjects(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path[len("/subjects/"):]
+	var subject, rest string
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' {
+			subject, rest = path[:i], path[i+1:]
+			break
+		}
+	}
+	if rest == "" {
+		subject = path
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case rest == "" && r.Method == http.MethodDelete:
+		f.deleteSubject(w, r, subject)
+	case rest == "" && (r.Method == http.MethodPost || r.Method == http.MethodGet):
+		f.checkSchema(w, r, subject)
+	case rest == "versions" && r.Method == http.MethodGet:
+		f.listVersions(w, r, subject)
+	case rest == "versions" && r.Method == http.MethodPost:
+		f.createVersion(w, r, subject)
+	case len(rest) > len("versions/") && rest[:len("versions/")] == "versions/":
+		f.versionOp(w, r, subject, rest[len("versions/"):])
+	default:
+		writeError(w, http.StatusNotFound, 404, "not found")
+	}
+}
+
+func (f *FakeRegistryServer) checkSchema(w http.ResponseWriter, r *http.Request, subject string) {
+	var req struct {
+		Schema     string `json:"schema"`
+		SchemaType string `json:"schemaType"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, 400, "invalid payload")
+		return
+	}
+	for _, existing := range f.bySubject[subject] {
+		if existing.schema == req.Schema && !existing.deleted {
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"subject": subject,
+				"id":      existing.id,
+				"version": existing.version,
+				"schema":  existing.schema,
+			})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, 40403, "Schema not found")
+}
+
+func (f *FakeRegistryServer) createVersion(w http.ResponseWriter, r *http.Request, subject string) {
+	var req struct {
+		Schema     string `json:"schema"`
+		SchemaType string `json:"schemaType"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, 400, "invalid payload")
+		return
+	}
+	id, _ := f.register(subject, req.Schema, req.SchemaType)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"id": id})
+}
+
+func (f *FakeRegistryServer) listVersions(w http.ResponseWriter, r *http.Request, subject string) {
+	includeDeleted := r.URL.Query().Get("deleted") == "true"
+	var versions []int
+	for _, s := range f.bySubject[subject] {
+		if includeDeleted || !s.deleted {
+			versions = append(versions, s.version)
+		}
+	}
+	if versions == nil {
+		writeError(w, http.StatusNotFound, 40401, "Subject not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, versions)
+}
+
+func (f *FakeRegistryServer) versionOp(w http.ResponseWriter, r *http.Request, subject, versionStr string) {
+	switch r.Method {
+	case http.MethodGet:
+		f.getVersion(w, r, subject, versionStr)
+	case http.MethodDelete:
+		f.deleteVersion(w, r, subject, versionStr)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, 405, "method not allowed")
+	}
+}
+
+func (f *FakeRegistryServer) getVersion(w http.ResponseWriter, r *http.Request, subject, versionStr string) {
+	s := f.findVersion(subject, versionStr, r.URL.Query().Get("deleted") == "true")
+	if s == nil {
+		writeError(w, http.StatusNotFound, 40402, "Version not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"subject":    subject,
+		"id":         s.id,
+		"version":    s.version,
+		"schema":     s.schema,
+		"schemaType": s.schemaType,
+	})
+}
+
+func (f *FakeRegistryServer) findVersion(subject, versionStr string, includeDeleted bool) *storedSchema {
+	versions := f.bySubject[subject]
+	if len(versions) == 0 {
+		return nil
+	}
+	if versionStr == "latest" {
+		for i := len(versions) - 1; i >= 0; i-- {
+			if includeDeleted || !versions[i].deleted {
+				return versions[i]
+			}
+		}
+		return nil
+	}
+	version, err := strconv.Atoi(versionStr)
+	if err != nil {
+		return nil
+	}
+	for _, s := range versions {
+		if s.version == version && (includeDeleted || !s.deleted) {
+			return s
+		}
+	}
+	return nil
+}
+
+func (f *FakeRegistryServer) deleteSubject(w http.ResponseWriter, r *http.Request, subject string) {
+	versions, ok := f.bySubject[subject]
+	if !ok {
+		writeError(w, http.StatusNotFound, 40401, "Subject not found")
+		return
+	}
+	permanent := r.URL.Query().Get("permanent") == "true"
+	var result []int
+	for _, s := range versions {
+		result = append(result, s.version)
+		s.deleted = true
+	}
+	if permanent {
+		delete(f.bySubject, subject)
+		for _, s := range versions {
+			delete(f.byID, s.id)
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (f *FakeRegistryServer) deleteVersion(w http.ResponseWriter, r *http.Request, subject, versionStr string) {
+	s := f.findVersion(subject, versionStr, true)
+	if s == nil {
+		writeError(w, http.StatusNotFound, 40402, "Version not found")
+		return
+	}
+	permanent := r.URL.Query().Get("permanent") == "true"
+	s.deleted = true
+	if permanent {
+		delete(f.byID, s.id)
+	}
+	writeJSON(w, http.StatusOK, s.version)
+}
+
+func (f *FakeRegistryServer) handleCompatibility(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"is_compatible": true})
+}
+
+func (f *FakeRegistryServer) handleSubjectConfig(w http.ResponseWriter, r *http.Request) {
+	subject := r.URL.Path[len("/config/"):]
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		level, ok := f.compatibility[subject]
+		if !ok {
+			level = "BACKWARD"
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"compatibilityLevel": level})
+	case http.MethodPut:
+		var req struct {
+			Compatibility string `json:"compatibility"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, 400, "invalid payload")
+			return
+		}
+		f.compatibility[subject] = req.Compatibility
+		writeJSON(w, http.StatusOK, map[string]string{"compatibility": req.Compatibility})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, 405, "method not allowed")
+	}
+}
+
+func (f *FakeRegistryServer) handleGlobalConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"compatibilityLevel": "BACKWARD"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status, errorCode int, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error_code": errorCode,
+		"message":    fmt.Sprintf("%s", message),
+	})
+}
@@ -0,0 +1,81 @@
+package srtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestSeedThenGetByID(t *testing.T) {
+	server := NewFakeRegistryServer()
+	defer server.Close()
+
+	id, version := server.Seed("widget-value", `{"type":"record","name":"Widget","fields":[]}`, "AVRO")
+	if id == 0 {
+		t.Fatalf("Seed returned id 0")
+	}
+	if version != 1 {
+		t.Errorf("version = %d, want 1", version)
+	}
+
+	resp, err := http.Get(server.URL + "/schemas/ids/" + strconv.Itoa(id))
+	if err != nil {
+		t.Fatalf("GET /schemas/ids: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	var body struct {
+		Schema     string `json:"schema"`
+		SchemaType string `json:"schemaType"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if body.SchemaType != "AVRO" {
+		t.Errorf("schemaType = %q, want %q", body.SchemaType, "AVRO")
+	}
+}
+
+func TestRegisterIsIdempotentForIdenticalSchema(t *testing.T) {
+	server := NewFakeRegistryServer()
+	defer server.Close()
+
+	schema := `{"type":"record","name":"Widget","fields":[]}`
+	firstID, firstVersion := server.Seed("widget-value", schema, "AVRO")
+	secondID, secondVersion := server.Seed("widget-value", schema, "AVRO")
+
+	if firstID != secondID || firstVersion != secondVersion {
+		t.Errorf("re-registering an identical schema created a new version: (%d, %d) != (%d, %d)",
+			firstID, firstVersion, secondID, secondVersion)
+	}
+}
+
+func TestDeleteSubjectSoftDeleteHidesSchemaByID(t *testing.T) {
+	server := NewFakeRegistryServer()
+	defer server.Close()
+
+	id, _ := server.Seed("widget-value", `{"type":"record","name":"Widget","fields":[]}`, "AVRO")
+
+	req, _ := http.NewRequest(http.MethodDelete, server.URL+"/subjects/widget-value", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("DELETE /subjects: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	getResp, err := http.Get(server.URL + "/schemas/ids/" + strconv.Itoa(id))
+	if err != nil {
+		t.Fatalf("GET /schemas/ids after soft delete: %v", err)
+	}
+	getResp.Body.Close()
+	if getResp.StatusCode != http.StatusNotFound {
+		t.Errorf("status after soft delete = %d, want %d", getResp.StatusCode, http.StatusNotFound)
+	}
+}